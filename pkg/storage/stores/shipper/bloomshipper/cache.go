@@ -0,0 +1,37 @@
+package bloomshipper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+// BlocksCache is the embedded, size-bound LRU cache of downloaded blocks
+// kept in the shipper's on-disk working directory. It sits in front of
+// object storage so repeated queries for the same block don't re-download
+// it.
+type BlocksCache struct {
+	*cache.EmbeddedCache[string, BlockDirectory]
+}
+
+// NewBlocksCache builds a BlocksCache from the given configuration. The
+// embedded cache package is still go-kit based, so logger is downgraded via
+// Logger.GoKit() at this boundary.
+func NewBlocksCache(cfg cache.EmbeddedCacheConfig, reg prometheus.Registerer, logger *Logger) *BlocksCache {
+	return &BlocksCache{
+		EmbeddedCache: cache.NewTypedEmbeddedCache[string, BlockDirectory](
+			"bloom-blocks-cache",
+			cfg,
+			reg,
+			logger.GoKit(),
+			"bloomshipper",
+		),
+	}
+}
+
+// BlockDirectory is the on-disk location a block was unpacked into, plus
+// the ref it was downloaded for.
+type BlockDirectory struct {
+	BlockRef
+	Path string
+}
@@ -0,0 +1,131 @@
+package bloomshipper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/prometheus/common/model"
+
+	v1 "github.com/grafana/loki/pkg/storage/bloom/v1"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+)
+
+// Ref identifies a single bloom block or meta within a tenant's keyspace.
+type Ref struct {
+	TenantID       string
+	TableName      string
+	Bounds         v1.Bounds
+	StartTimestamp model.Time
+	EndTimestamp   model.Time
+}
+
+// MetaRef identifies a meta object in object storage.
+type MetaRef struct {
+	Ref
+}
+
+// Addr returns this meta's object storage key.
+func (r MetaRef) Addr() string {
+	return metaPath(r).Addr()
+}
+
+// BlockRef identifies a bloom block object in object storage.
+type BlockRef struct {
+	Ref
+}
+
+// Addr returns this block's object storage key.
+func (r BlockRef) Addr() string {
+	return blockPath(r).Addr()
+}
+
+// Meta describes the set of blocks covering a tenant's keyspace for a given
+// table and time range.
+type Meta struct {
+	MetaRef
+	Blocks []BlockRef
+}
+
+// Block is a bloom block together with a handle to its (possibly
+// compressed, tar'd) contents.
+type Block struct {
+	BlockRef
+	Data io.ReadSeekCloser
+}
+
+// Interval is a half-open time range [Start, End).
+type Interval struct {
+	Start, End model.Time
+}
+
+// NewInterval returns the interval [start, end).
+func NewInterval(start, end model.Time) Interval {
+	return Interval{Start: start, End: end}
+}
+
+// Overlaps reports whether the two intervals share any instant in time.
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start < other.End && other.Start < i.End
+}
+
+// String renders the interval for logging.
+func (i Interval) String() string {
+	return fmt.Sprintf("%s-%s", i.Start, i.End)
+}
+
+// MetaSearchParams scopes a meta lookup to a tenant, a time range and a
+// fingerprint keyspace.
+type MetaSearchParams struct {
+	TenantID string
+	Interval Interval
+	Keyspace v1.Bounds
+}
+
+// keyPath is an object storage location. It implements Addr so callers can
+// build the final object key without knowing the exact layout.
+type keyPath struct {
+	prefix string
+	parts  []string
+}
+
+// Addr returns the object storage key for this path.
+func (k keyPath) Addr() string {
+	return path.Join(append([]string{k.prefix}, k.parts...)...)
+}
+
+func metaPath(ref MetaRef) keyPath {
+	return keyPath{
+		prefix: "bloom",
+		parts: []string{
+			ref.TableName,
+			ref.TenantID,
+			"metas",
+			fmt.Sprintf("%s-%s", ref.Bounds.String(), ref.Ref.StartTimestamp),
+		},
+	}
+}
+
+func blockPath(ref BlockRef) keyPath {
+	return keyPath{
+		prefix: "bloom",
+		parts: []string{
+			ref.TableName,
+			ref.TenantID,
+			"blocks",
+			fmt.Sprintf("%s-%d-%d", ref.Bounds.String(), ref.StartTimestamp, ref.EndTimestamp),
+		},
+	}
+}
+
+// Client is the object storage surface the bloom shipper needs: enough of
+// client.ObjectClient to read and write metas/blocks, plus a way to tell a
+// deleted object apart from a transient error.
+type Client interface {
+	PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error
+	GetObject(ctx context.Context, objectKey string) (io.ReadCloser, int64, error)
+	List(ctx context.Context, prefix, delimiter string) ([]client.StorageObject, []client.StorageCommonPrefix, error)
+	IsObjectNotFoundErr(err error) bool
+	Stop()
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"flag"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+// Config configures the bloom shipper, which keeps bloom blocks and their
+// metas synced between object storage and the on-disk/meta caches used by
+// queriers and compactors.
+type Config struct {
+	WorkingDirectory       string                    `yaml:"working_directory"`
+	BlocksDownloadingQueue DownloadingQueueConfig    `yaml:"blocks_downloading_queue"`
+	BlocksCache            cache.EmbeddedCacheConfig `yaml:"blocks_cache"`
+}
+
+// RegisterFlags registers flags for the bloom shipper configuration.
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	c.RegisterFlagsWithPrefix("bloom-shipper.", f)
+}
+
+// RegisterFlagsWithPrefix registers flags for the bloom shipper configuration with the given prefix.
+func (c *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&c.WorkingDirectory, prefix+"working-directory", "", "Directory where bloom blocks and metas are downloaded to for local querying.")
+	c.BlocksDownloadingQueue.RegisterFlagsWithPrefix(prefix+"blocks-downloading-queue.", f)
+	c.BlocksCache.RegisterFlagsWithPrefix(prefix+"blocks-cache.", f, "Cache for bloom blocks. ")
+}
+
+// DownloadingQueueConfig configures the queue that downloads blocks that
+// were not found in the local on-disk cache. BloomStore does not read it:
+// FetchBlocks downloads missing blocks one at a time in the calling
+// goroutine.
+type DownloadingQueueConfig struct {
+	WorkersCount int `yaml:"workers_count"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the downloading queue configuration with the given prefix.
+func (cfg *DownloadingQueueConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.IntVar(&cfg.WorkersCount, prefix+"workers-count", 4, "Number of workers used to download blocks that were not found locally.")
+}
@@ -0,0 +1,64 @@
+package bloomshipper
+
+import (
+	"os"
+	"strings"
+)
+
+// fsFailure injects err for every operation whose path starts with prefix.
+type fsFailure struct {
+	prefix string
+	err    error
+}
+
+// fakeFS wraps the real OS filesystem but lets tests force specific paths
+// to fail with a chosen error (syscall.EACCES, syscall.ENOSPC, a timeout,
+// ...). This makes OS-failure behavior deterministic across platforms and
+// independent of the user running the tests, unlike chmod-based tricks.
+type fakeFS struct {
+	mkdirFailures []fsFailure
+	openFailures  []fsFailure
+}
+
+func newFakeFS() *fakeFS {
+	return &fakeFS{}
+}
+
+// failMkdirAll makes MkdirAll return err for any path starting with prefix.
+func (f *fakeFS) failMkdirAll(prefix string, err error) {
+	f.mkdirFailures = append(f.mkdirFailures, fsFailure{prefix, err})
+}
+
+// failOpenFile makes OpenFile return err for any path starting with prefix.
+func (f *fakeFS) failOpenFile(prefix string, err error) {
+	f.openFailures = append(f.openFailures, fsFailure{prefix, err})
+}
+
+func matchFailure(failures []fsFailure, path string) error {
+	for _, failure := range failures {
+		if strings.HasPrefix(path, failure.prefix) {
+			return failure.err
+		}
+	}
+	return nil
+}
+
+func (f *fakeFS) MkdirAll(path string, perm os.FileMode) error {
+	if err := matchFailure(f.mkdirFailures, path); err != nil {
+		return err
+	}
+	return os.MkdirAll(path, perm)
+}
+
+func (f *fakeFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if err := matchFailure(f.openFailures, name); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(name, flag, perm)
+}
+
+func (f *fakeFS) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (f *fakeFS) Remove(name string) error { return os.Remove(name) }
+
+func (f *fakeFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
@@ -0,0 +1,230 @@
+package bloomshipper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+// MetaFilter lets callers inspect or mutate the set of metas a MetaFetcher
+// loaded for a sync before they are handed back to the caller. Filters run
+// in registration order and must not retain metas after Filter returns.
+// Typical uses are tenant allow-listing, deduplication, or excluding metas
+// that are too recent to be considered consistent (consistency-delay).
+type MetaFilter interface {
+	Filter(ctx context.Context, metas map[MetaRef]Meta) error
+}
+
+// MetaFilterFunc adapts a plain function to a MetaFilter.
+type MetaFilterFunc func(ctx context.Context, metas map[MetaRef]Meta) error
+
+// Filter implements MetaFilter.
+func (f MetaFilterFunc) Filter(ctx context.Context, metas map[MetaRef]Meta) error {
+	return f(ctx, metas)
+}
+
+var errMetaCacheMiss = errors.New("meta not found in cache")
+
+// MetaFetcher owns syncing meta JSONs between the meta cache and object
+// storage for a single period/schema entry. It is the bloomshipper
+// counterpart of Thanos' block.MetaFetcher: a corrupted cache entry is
+// recovered transparently from object storage instead of failing the
+// caller, and metas that are not requested anymore or that object storage
+// reports as missing are evicted from the in-memory view rather than
+// surfaced as errors. A meta served from the meta cache is not checked
+// against object storage.
+type MetaFetcher struct {
+	objectClient Client
+	metaCache    cache.Cache
+	logger       *Logger
+	metrics      *fetcherMetrics
+
+	filters []MetaFilter
+
+	mu    sync.Mutex
+	metas map[MetaRef]Meta
+}
+
+// NewMetaFetcher creates a MetaFetcher backed by objectClient and metaCache.
+// metrics is shared across every MetaFetcher of a BloomStore: each fetcher
+// only ever covers one period/schema of the same store, and registering the
+// same collectors once per period against the caller's registerer would
+// panic on duplicate registration.
+func NewMetaFetcher(logger *Logger, objectClient Client, metaCache cache.Cache, metrics *fetcherMetrics) *MetaFetcher {
+	return &MetaFetcher{
+		objectClient: objectClient,
+		metaCache:    metaCache,
+		logger:       logger.With("component", "meta-fetcher"),
+		metrics:      metrics,
+		metas:        map[MetaRef]Meta{},
+	}
+}
+
+// AddFilter registers a MetaFilter that runs at the end of every FetchMetas
+// call. Not safe to call concurrently with FetchMetas.
+func (f *MetaFetcher) AddFilter(filter MetaFilter) {
+	f.filters = append(f.filters, filter)
+}
+
+// FetchMetas resolves refs to their Meta contents, preferring the meta
+// cache and falling back to object storage on a cache miss or a corrupted
+// cache entry. Refs that miss the cache and are no longer present in object
+// storage are dropped silently and evicted from the fetcher's in-memory
+// view; a ref whose meta is still in the cache is returned from the cache
+// even if it has been deleted from object storage.
+func (f *MetaFetcher) FetchMetas(ctx context.Context, refs []MetaRef) ([]Meta, error) {
+	start := time.Now()
+	logger := f.logger.WithContext(ctx)
+	f.metrics.syncsTotal.Inc()
+
+	loaded := make(map[MetaRef]Meta, len(refs))
+	var deleted []MetaRef
+	var dropped int
+
+	for _, ref := range refs {
+		meta, err := f.loadMeta(ctx, logger, ref)
+		if err != nil {
+			f.metrics.syncFailuresTotal.Inc()
+			return nil, fmt.Errorf("failed to load meta %s: %w", ref.Addr(), err)
+		}
+		if meta == nil {
+			dropped++
+			deleted = append(deleted, ref)
+			continue
+		}
+		loaded[ref] = *meta
+	}
+
+	f.evict(logger, refs, deleted)
+
+	for _, filter := range f.filters {
+		if err := filter.Filter(ctx, loaded); err != nil {
+			f.metrics.syncFailuresTotal.Inc()
+			return nil, fmt.Errorf("meta filter: %w", err)
+		}
+	}
+
+	f.mu.Lock()
+	for ref, meta := range loaded {
+		f.metas[ref] = meta
+	}
+	f.mu.Unlock()
+
+	f.metrics.metasLoadedTotal.Add(float64(len(loaded)))
+	f.metrics.metasDroppedTotal.Add(float64(dropped))
+	f.metrics.syncsSucceededTotal.Inc()
+	f.metrics.syncDuration.Observe(time.Since(start).Seconds())
+	logger.Debug("synced metas", "loaded", len(loaded), "dropped", dropped, "duration_ms", time.Since(start).Milliseconds())
+
+	result := make([]Meta, 0, len(loaded))
+	for _, meta := range loaded {
+		result = append(result, meta)
+	}
+	return result, nil
+}
+
+// evict drops every meta the fetcher is currently holding that is either no
+// longer part of the requested ref set, or was requested but confirmed
+// deleted from object storage by this sync.
+func (f *MetaFetcher) evict(logger *Logger, wanted, deleted []MetaRef) {
+	want := make(map[MetaRef]struct{}, len(wanted))
+	for _, ref := range wanted {
+		want[ref] = struct{}{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ref := range f.metas {
+		if _, ok := want[ref]; !ok {
+			delete(f.metas, ref)
+			logger.Debug("evicted meta no longer requested", "meta_ref", ref.Addr())
+		}
+	}
+	for _, ref := range deleted {
+		if _, ok := f.metas[ref]; ok {
+			delete(f.metas, ref)
+			logger.Debug("evicted meta deleted from storage", "meta_ref", ref.Addr())
+		}
+	}
+}
+
+// Cached returns the metas currently held by the fetcher, keyed by ref: the
+// metas loaded by successful syncs, less any evicted by a later sync. It
+// gives the fetcher's post-eviction view without re-syncing.
+func (f *MetaFetcher) Cached() map[MetaRef]Meta {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	metas := make(map[MetaRef]Meta, len(f.metas))
+	for ref, meta := range f.metas {
+		metas[ref] = meta
+	}
+	return metas
+}
+
+func (f *MetaFetcher) loadMeta(ctx context.Context, logger *Logger, ref MetaRef) (*Meta, error) {
+	logger = logger.With("tenant", ref.TenantID, "table", ref.TableName, "meta_ref", ref.Addr())
+
+	var corrupted bool
+	if meta, err := f.readFromCache(ctx, ref); err == nil {
+		return meta, nil
+	} else if !errors.Is(err, errMetaCacheMiss) {
+		logger.Warn("corrupted meta in cache, refetching from storage", "err", err)
+		corrupted = true
+	}
+
+	rc, n, err := f.objectClient.GetObject(ctx, ref.Addr())
+	if err != nil {
+		if f.objectClient.IsObjectNotFoundErr(err) {
+			// meta is gone from storage: report it as a deletion rather
+			// than an error so callers don't need special-case handling.
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	buf, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read meta from storage: %w", err)
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(buf, &meta); err != nil {
+		return nil, fmt.Errorf("unmarshal meta from storage: %w", err)
+	}
+
+	if corrupted {
+		f.metrics.corruptedCacheEntriesTotal.Inc()
+	}
+	f.writeToCache(ctx, logger, ref, buf)
+	logger.Debug("loaded meta from storage", "bytes", n, "cache_hit", false)
+	return &meta, nil
+}
+
+func (f *MetaFetcher) readFromCache(ctx context.Context, ref MetaRef) (*Meta, error) {
+	found, bufs, _, err := f.metaCache.Fetch(ctx, []string{ref.Addr()})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, errMetaCacheMiss
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(bufs[0], &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (f *MetaFetcher) writeToCache(ctx context.Context, logger *Logger, ref MetaRef, raw []byte) {
+	if err := f.metaCache.Store(ctx, []string{ref.Addr()}, [][]byte{raw}); err != nil {
+		logger.Warn("failed to store meta in cache", "err", err)
+	}
+}
@@ -0,0 +1,146 @@
+package bloomshipper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+)
+
+// fakeMetaClient is a minimal in-memory Client for fetcher- and store-level
+// tests: GetObject and PutObject work on a map keyed by object key, and List
+// returns every object whose key starts with the given prefix.
+type fakeMetaClient struct {
+	objects map[string][]byte
+}
+
+func newFakeMetaClient() *fakeMetaClient {
+	return &fakeMetaClient{objects: map[string][]byte{}}
+}
+
+func (c *fakeMetaClient) put(t *testing.T, ref MetaRef, meta Meta) {
+	t.Helper()
+	raw, err := json.Marshal(meta)
+	require.NoError(t, err)
+	c.objects[ref.Addr()] = raw
+}
+
+func (c *fakeMetaClient) delete(ref MetaRef) {
+	delete(c.objects, ref.Addr())
+}
+
+func (c *fakeMetaClient) putRaw(addr string, raw []byte) {
+	c.objects[addr] = raw
+}
+
+func (c *fakeMetaClient) PutObject(_ context.Context, objectKey string, object io.ReadSeeker) error {
+	raw, err := io.ReadAll(object)
+	if err != nil {
+		return err
+	}
+	c.objects[objectKey] = raw
+	return nil
+}
+
+func (c *fakeMetaClient) GetObject(_ context.Context, objectKey string) (io.ReadCloser, int64, error) {
+	raw, ok := c.objects[objectKey]
+	if !ok {
+		return nil, 0, errObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(raw)), int64(len(raw)), nil
+}
+
+func (c *fakeMetaClient) List(_ context.Context, prefix, _ string) ([]client.StorageObject, []client.StorageCommonPrefix, error) {
+	var objects []client.StorageObject
+	for key := range c.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, client.StorageObject{Key: key})
+		}
+	}
+	return objects, nil, nil
+}
+
+func (c *fakeMetaClient) IsObjectNotFoundErr(err error) bool {
+	return errors.Is(err, errObjectNotFound)
+}
+
+func (c *fakeMetaClient) Stop() {}
+
+var errObjectNotFound = errors.New("object not found")
+
+func newTestMetaFetcher() (*MetaFetcher, *fakeMetaClient) {
+	objectClient := newFakeMetaClient()
+	metaCache := cache.NewMockCache()
+	logger := NewLogger(log.NewLogfmtLogger(os.Stderr))
+	fetcher := NewMetaFetcher(logger, objectClient, metaCache, newFetcherMetrics(prometheus.NewPedanticRegistry()))
+	return fetcher, objectClient
+}
+
+func TestMetaFetcher_RecoversFromCorruptedCacheEntry(t *testing.T) {
+	fetcher, objectClient := newTestMetaFetcher()
+	ctx := context.Background()
+
+	ref := MetaRef{Ref: Ref{TenantID: "tenant", TableName: "table"}}
+	meta := Meta{MetaRef: ref}
+	objectClient.put(t, ref, meta)
+
+	// Corrupt the cache entry directly so the fetcher has to fall back to
+	// object storage instead of trusting it.
+	err := fetcher.metaCache.Store(ctx, []string{ref.Addr()}, [][]byte{[]byte("not valid json")})
+	require.NoError(t, err)
+
+	metas, err := fetcher.FetchMetas(ctx, []MetaRef{ref})
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Equal(t, ref, metas[0].MetaRef)
+}
+
+func TestMetaFetcher_EvictsDeletedMetas(t *testing.T) {
+	fetcher, objectClient := newTestMetaFetcher()
+	ctx := context.Background()
+
+	ref := MetaRef{Ref: Ref{TenantID: "tenant", TableName: "table"}}
+	meta := Meta{MetaRef: ref}
+	objectClient.put(t, ref, meta)
+
+	metas, err := fetcher.FetchMetas(ctx, []MetaRef{ref})
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Contains(t, fetcher.Cached(), ref)
+
+	// The meta disappears from storage (e.g. compaction deleted it), but is
+	// still requested on the next sync.
+	objectClient.delete(ref)
+
+	metas, err = fetcher.FetchMetas(ctx, []MetaRef{ref})
+	require.NoError(t, err)
+	require.Len(t, metas, 0)
+	require.NotContains(t, fetcher.Cached(), ref)
+}
+
+func TestMetaFetcher_FilterRejection(t *testing.T) {
+	fetcher, objectClient := newTestMetaFetcher()
+	ctx := context.Background()
+
+	ref := MetaRef{Ref: Ref{TenantID: "tenant", TableName: "table"}}
+	objectClient.put(t, ref, Meta{MetaRef: ref})
+
+	errFiltered := errors.New("tenant not allowed")
+	fetcher.AddFilter(MetaFilterFunc(func(context.Context, map[MetaRef]Meta) error {
+		return errFiltered
+	}))
+
+	_, err := fetcher.FetchMetas(ctx, []MetaRef{ref})
+	require.ErrorIs(t, err, errFiltered)
+}
@@ -0,0 +1,47 @@
+package bloomshipper
+
+import (
+	"io"
+	"os"
+)
+
+// File is the subset of *os.File operations the shipper needs from a
+// filesystem abstraction.
+type File interface {
+	io.ReadWriteCloser
+	io.Seeker
+}
+
+// FS abstracts the filesystem operations the bloom shipper performs against
+// its working directory: creating the directory tree, writing downloaded
+// blocks, and probing the directory for write access. Production code
+// always uses osFS; tests can substitute an implementation that injects
+// specific OS-level failures (permission errors, disk-full, transient I/O
+// errors) deterministically,
+// which chmod-based tricks can't do reliably across platforms or when
+// running as root.
+type FS interface {
+	MkdirAll(path string, perm os.FileMode) error
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Remove(name string) error
+	Rename(oldpath, newpath string) error
+}
+
+// osFS is the default, OS-backed FS implementation.
+type osFS struct{}
+
+// defaultFS is used whenever a caller does not supply its own FS.
+var defaultFS FS = osFS{}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (osFS) Remove(name string) error { return os.Remove(name) }
+
+func (osFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
@@ -0,0 +1,125 @@
+package bloomshipper
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Logger emits structured log fields for bloomshipper operations. It wraps
+// a *slog.Logger internally but can be built from either a *slog.Logger or
+// a go-kit log.Logger, since the rest of Loki still configures the latter.
+type Logger struct {
+	slog *slog.Logger
+}
+
+// NewLogger builds a Logger backed by a go-kit logger. All levels are
+// emitted; use NewLoggerAtLevel to suppress Debug lines (or anything below a
+// chosen minimum) in production.
+func NewLogger(logger log.Logger) *Logger {
+	return NewLoggerAtLevel(logger, slog.LevelDebug)
+}
+
+// NewLoggerAtLevel builds a Logger backed by a go-kit logger that only
+// emits records at or above minLevel.
+func NewLoggerAtLevel(logger log.Logger, minLevel slog.Level) *Logger {
+	return &Logger{slog: slog.New(newGoKitHandler(logger, minLevel))}
+}
+
+// NewSlogLogger builds a Logger backed directly by a *slog.Logger.
+func NewSlogLogger(logger *slog.Logger) *Logger {
+	return &Logger{slog: logger}
+}
+
+// With returns a Logger that always includes the given key/value pairs.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{slog: l.slog.With(args...)}
+}
+
+// WithContext returns a Logger that includes the tenant and trace IDs found
+// on ctx, if any, so every meta/block fetch can be correlated back to the
+// query that triggered it.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	var args []any
+	if tenantID, err := tenant.TenantID(ctx); err == nil && tenantID != "" {
+		args = append(args, "tenant", tenantID)
+	}
+	if traceID, ok := traceIDFromContext(ctx); ok {
+		args = append(args, "trace_id", traceID)
+	}
+	if len(args) == 0 {
+		return l
+	}
+	return l.With(args...)
+}
+
+// GoKit returns a go-kit log.Logger view of l, for passing into
+// dependencies (like the embedded cache package) that haven't migrated off
+// go-kit yet.
+func (l *Logger) GoKit() log.Logger {
+	return log.LoggerFunc(func(kvs ...interface{}) error {
+		args := make([]any, len(kvs))
+		copy(args, kvs)
+		l.slog.Info("", args...)
+		return nil
+	})
+}
+
+func (l *Logger) Debug(msg string, args ...any) { l.slog.Debug(msg, args...) }
+func (l *Logger) Info(msg string, args ...any)  { l.slog.Info(msg, args...) }
+func (l *Logger) Warn(msg string, args ...any)  { l.slog.Warn(msg, args...) }
+func (l *Logger) Error(msg string, args ...any) { l.slog.Error(msg, args...) }
+
+func traceIDFromContext(ctx context.Context) (string, bool) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return "", false
+	}
+	type traceIDer interface{ TraceID() string }
+	if sc, ok := span.Context().(traceIDer); ok {
+		return sc.TraceID(), true
+	}
+	return "", false
+}
+
+// goKitHandler adapts a go-kit log.Logger to a slog.Handler so the rest of
+// the package can use structured slog fields without forcing every caller
+// of bloomshipper to switch off go-kit.
+type goKitHandler struct {
+	logger   log.Logger
+	attrs    []slog.Attr
+	minLevel slog.Level
+}
+
+func newGoKitHandler(logger log.Logger, minLevel slog.Level) *goKitHandler {
+	return &goKitHandler{logger: logger, minLevel: minLevel}
+}
+
+func (h *goKitHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.minLevel
+}
+
+func (h *goKitHandler) Handle(_ context.Context, r slog.Record) error {
+	kvs := make([]any, 0, 4+2*(len(h.attrs)+r.NumAttrs()))
+	kvs = append(kvs, "level", r.Level.String(), "msg", r.Message)
+	for _, a := range h.attrs {
+		kvs = append(kvs, a.Key, a.Value.Any())
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = append(kvs, a.Key, a.Value.Any())
+		return true
+	})
+	return h.logger.Log(kvs...)
+}
+
+func (h *goKitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	merged = append(merged, attrs...)
+	return &goKitHandler{logger: h.logger, attrs: merged, minLevel: h.minLevel}
+}
+
+func (h *goKitHandler) WithGroup(string) slog.Handler { return h }
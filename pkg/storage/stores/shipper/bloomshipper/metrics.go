@@ -0,0 +1,62 @@
+package bloomshipper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "loki_bloom_store"
+
+// fetcherMetrics tracks the outcome of every MetaFetcher sync so operators
+// can tell cache corruption and storage unavailability apart from normal
+// operation.
+type fetcherMetrics struct {
+	syncsTotal                 prometheus.Counter
+	syncsSucceededTotal        prometheus.Counter
+	syncFailuresTotal          prometheus.Counter
+	corruptedCacheEntriesTotal prometheus.Counter
+	metasLoadedTotal           prometheus.Counter
+	metasDroppedTotal          prometheus.Counter
+	syncDuration               prometheus.Histogram
+}
+
+func newFetcherMetrics(r prometheus.Registerer) *fetcherMetrics {
+	return &fetcherMetrics{
+		syncsTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "meta_syncs_total",
+			Help:      "Total number of meta syncs attempted by the meta fetcher.",
+		}),
+		syncsSucceededTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "meta_syncs_succeeded_total",
+			Help:      "Total number of meta syncs that completed successfully.",
+		}),
+		syncFailuresTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "meta_sync_failures_total",
+			Help:      "Total number of meta syncs that failed.",
+		}),
+		corruptedCacheEntriesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "meta_cache_corrupted_entries_total",
+			Help:      "Total number of corrupted meta cache entries that were recovered from object storage.",
+		}),
+		metasLoadedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "metas_loaded_total",
+			Help:      "Total number of metas loaded by the meta fetcher across all syncs.",
+		}),
+		metasDroppedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "metas_dropped_total",
+			Help:      "Total number of metas dropped (no longer present in object storage) across all syncs.",
+		}),
+		syncDuration: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "meta_sync_duration_seconds",
+			Help:      "Time spent syncing metas from cache/object storage.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+}
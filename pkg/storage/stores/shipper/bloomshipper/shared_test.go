@@ -0,0 +1,25 @@
+package bloomshipper
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	storageconfig "github.com/grafana/loki/pkg/storage/config"
+)
+
+func mustParse(layout, value string) time.Time {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseTime(s string) model.Time {
+	return model.TimeFromUnixNano(mustParse("2006-01-02 15:04", s).UnixNano())
+}
+
+func parseDayTime(s string) storageconfig.DayTime {
+	return storageconfig.DayTime{Time: model.TimeFromUnixNano(mustParse("2006-01-02", s).UnixNano())}
+}
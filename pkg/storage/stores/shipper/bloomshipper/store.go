@@ -0,0 +1,433 @@
+package bloomshipper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	storageconfig "github.com/grafana/loki/pkg/storage/config"
+)
+
+// errReadOnly is returned by every write path of a read-only BloomStore.
+var errReadOnly = errors.New("bloom store is read-only")
+
+// bloomStoreEntry is the per-period/schema slice of a BloomStore: its own
+// object client(s) and its own meta fetcher, so that schema changes (bucket
+// moves, new object store backends, ...) don't require migrating history.
+//
+// objectClient is the primary, read-write bucket used by writers (the
+// compactor and ingesters). mirrorClient, when set, is a separate read-only
+// bucket (e.g. a replicated or cached copy) that reads are routed to
+// instead, so queriers can run against immutable storage without risking
+// accidental mutation of the authoritative bucket.
+type bloomStoreEntry struct {
+	cfg          storageconfig.PeriodConfig
+	objectClient Client
+	mirrorClient Client
+	fetcher      *MetaFetcher
+}
+
+// readClient returns the client reads should use: the mirror if one is
+// configured, otherwise the primary.
+func (b *bloomStoreEntry) readClient() Client {
+	if b.mirrorClient != nil {
+		return b.mirrorClient
+	}
+	return b.objectClient
+}
+
+// Meta returns the object storage location of ref.
+func (b *bloomStoreEntry) Meta(ref MetaRef) keyPath {
+	return metaPath(ref)
+}
+
+// Block returns the object storage location of ref.
+func (b *bloomStoreEntry) Block(ref BlockRef) keyPath {
+	return blockPath(ref)
+}
+
+// BloomStore resolves and fetches bloom metas and blocks across the
+// period/schema configs of a single tenant-agnostic deployment. It fans out
+// reads to whichever bloomStoreEntry covers a given point in time and
+// routes writes (PutMeta, PutBlock) via storeDo to that entry's primary
+// object client.
+type BloomStore struct {
+	stores        []*bloomStoreEntry
+	storageConfig storage.Config
+	blocksCache   *BlocksCache
+	workingDir    string
+	fs            FS
+	logger        *Logger
+	readOnly      bool
+}
+
+// NewBloomStore builds a read-write BloomStore with one bloomStoreEntry per
+// period config that has an object store configured. It uses the OS
+// filesystem for its working directory; use NewBloomStoreWithFS to inject a
+// different implementation (tests use this to simulate disk-full or
+// permission failures deterministically).
+func NewBloomStore(
+	periodicConfigs []storageconfig.PeriodConfig,
+	storageConfig storage.Config,
+	clientMetrics storage.ClientMetrics,
+	metasCache cache.Cache,
+	blocksCache *BlocksCache,
+	reg prometheus.Registerer,
+	logger *Logger,
+) (*BloomStore, error) {
+	return NewBloomStoreWithFS(periodicConfigs, storageConfig, clientMetrics, metasCache, blocksCache, defaultFS, reg, logger)
+}
+
+// NewBloomStoreWithFS is NewBloomStore with an explicit FS for the working
+// directory.
+func NewBloomStoreWithFS(
+	periodicConfigs []storageconfig.PeriodConfig,
+	storageConfig storage.Config,
+	clientMetrics storage.ClientMetrics,
+	metasCache cache.Cache,
+	blocksCache *BlocksCache,
+	fs FS,
+	reg prometheus.Registerer,
+	logger *Logger,
+) (*BloomStore, error) {
+	return newBloomStore(periodicConfigs, nil, storageConfig, clientMetrics, metasCache, blocksCache, fs, false, reg, logger)
+}
+
+// NewReadOnlyBloomStore builds a BloomStore that never writes to object
+// storage: PutMeta and PutBlock fail immediately, and downloaded blocks are
+// not written back to the local on-disk cache. mirrorConfigs, when
+// non-nil, must have the same length as periodicConfigs and points each
+// period at a separate (e.g. replicated or cached) bucket that reads are
+// served from instead of the primary, so operators can run queriers
+// against immutable storage without risking accidental mutation of the
+// authoritative bucket.
+func NewReadOnlyBloomStore(
+	periodicConfigs []storageconfig.PeriodConfig,
+	mirrorConfigs []storageconfig.PeriodConfig,
+	storageConfig storage.Config,
+	clientMetrics storage.ClientMetrics,
+	metasCache cache.Cache,
+	blocksCache *BlocksCache,
+	reg prometheus.Registerer,
+	logger *Logger,
+) (*BloomStore, error) {
+	return newBloomStore(periodicConfigs, mirrorConfigs, storageConfig, clientMetrics, metasCache, blocksCache, defaultFS, true, reg, logger)
+}
+
+func newBloomStore(
+	periodicConfigs []storageconfig.PeriodConfig,
+	mirrorConfigs []storageconfig.PeriodConfig,
+	storageConfig storage.Config,
+	clientMetrics storage.ClientMetrics,
+	metasCache cache.Cache,
+	blocksCache *BlocksCache,
+	fs FS,
+	readOnly bool,
+	reg prometheus.Registerer,
+	logger *Logger,
+) (*BloomStore, error) {
+	if mirrorConfigs != nil && len(mirrorConfigs) != len(periodicConfigs) {
+		return nil, fmt.Errorf("mirror storage config must have one entry per period config, got %d for %d periods", len(mirrorConfigs), len(periodicConfigs))
+	}
+
+	wd := storageConfig.BloomShipperConfig.WorkingDirectory
+	if err := ensureWorkingDirectory(fs, wd); err != nil {
+		return nil, err
+	}
+
+	store := &BloomStore{
+		storageConfig: storageConfig,
+		blocksCache:   blocksCache,
+		workingDir:    wd,
+		fs:            fs,
+		logger:        logger,
+		readOnly:      readOnly,
+	}
+
+	// Shared across every entry: each entry's fetcher covers a different
+	// period/schema of the same store, so they'd otherwise register the
+	// same collector names twice against reg.
+	fetcherMetrics := newFetcherMetrics(reg)
+
+	for i, cfg := range periodicConfigs {
+		if cfg.ObjectType == "" {
+			continue
+		}
+
+		objectClient, err := storage.NewObjectClient(cfg.ObjectType, storageConfig, clientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create object client for period starting %s: %w", cfg.From, err)
+		}
+
+		entry := &bloomStoreEntry{cfg: cfg, objectClient: objectClient}
+
+		if mirrorConfigs != nil && mirrorConfigs[i].ObjectType != "" {
+			mirrorClient, err := storage.NewObjectClient(mirrorConfigs[i].ObjectType, storageConfig, clientMetrics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create mirror object client for period starting %s: %w", cfg.From, err)
+			}
+			entry.mirrorClient = mirrorClient
+		}
+
+		entry.fetcher = NewMetaFetcher(logger, entry.readClient(), metasCache, fetcherMetrics)
+		store.stores = append(store.stores, entry)
+	}
+
+	return store, nil
+}
+
+// Stop releases the resources held by every underlying store entry.
+func (b *BloomStore) Stop() {
+	for _, s := range b.stores {
+		s.objectClient.Stop()
+		if s.mirrorClient != nil {
+			s.mirrorClient.Stop()
+		}
+	}
+}
+
+// storeDo runs fn against whichever store entry is responsible for ts.
+func (b *BloomStore) storeDo(ts model.Time, fn func(s *bloomStoreEntry) error) error {
+	for i := len(b.stores) - 1; i >= 0; i-- {
+		if !ts.Before(b.stores[i].cfg.From.Time) {
+			return fn(b.stores[i])
+		}
+	}
+	if len(b.stores) == 0 {
+		return fmt.Errorf("no store found for timestamp %s", ts.Time())
+	}
+	return fn(b.stores[0])
+}
+
+// storesForInterval returns every store entry that overlaps interval, in
+// chronological order.
+func (b *BloomStore) storesForInterval(interval Interval) []*bloomStoreEntry {
+	var entries []*bloomStoreEntry
+	for i, s := range b.stores {
+		from := s.cfg.From.Time
+		var until model.Time
+		if i+1 < len(b.stores) {
+			until = b.stores[i+1].cfg.From.Time
+		} else {
+			until = model.Latest
+		}
+		if interval.Overlaps(NewInterval(from, until)) {
+			entries = append(entries, s)
+		}
+	}
+	return entries
+}
+
+// ResolveMetas lists the MetaRefs matching params, grouped by the store
+// entry (period/schema) they belong to, along with the MetaFetcher that
+// should be used to load each group.
+func (b *BloomStore) ResolveMetas(ctx context.Context, params MetaSearchParams) ([][]MetaRef, []*MetaFetcher, error) {
+	refs := make([][]MetaRef, 0)
+	fetchers := make([]*MetaFetcher, 0)
+
+	for _, s := range b.storesForInterval(params.Interval) {
+		tables := tablesForRange(s.cfg, params.Interval)
+		var found []MetaRef
+		for _, table := range tables {
+			prefix := fmt.Sprintf("bloom/%s/%s/metas/", table, params.TenantID)
+			objects, _, err := s.readClient().List(ctx, prefix, "")
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to list metas in table %s: %w", table, err)
+			}
+			for _, obj := range objects {
+				ref, err := parseMetaRef(obj.Key)
+				if err != nil {
+					continue
+				}
+				if ref.TenantID != params.TenantID {
+					continue
+				}
+				if !params.Keyspace.Overlaps(ref.Bounds) {
+					continue
+				}
+				found = append(found, ref)
+			}
+		}
+		if len(found) > 0 {
+			refs = append(refs, found)
+			fetchers = append(fetchers, s.fetcher)
+		}
+	}
+
+	return refs, fetchers, nil
+}
+
+// FetchMetas resolves and loads every Meta matching params, sorted by
+// start time.
+func (b *BloomStore) FetchMetas(ctx context.Context, params MetaSearchParams) ([]Meta, error) {
+	start := time.Now()
+	logger := b.logger.WithContext(ctx).With("tenant", params.TenantID, "bounds", params.Interval.String())
+
+	refs, fetchers, err := b.ResolveMetas(ctx, params)
+	if err != nil {
+		logger.Error("failed to resolve metas", "err", err, "duration_ms", time.Since(start).Milliseconds())
+		return nil, err
+	}
+
+	results := make([]Meta, 0)
+	for i, group := range refs {
+		metas, err := fetchers[i].FetchMetas(ctx, group)
+		if err != nil {
+			logger.Error("failed to fetch metas", "err", err, "duration_ms", time.Since(start).Milliseconds())
+			return nil, err
+		}
+		results = append(results, metas...)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Ref.StartTimestamp < results[j].Ref.StartTimestamp
+	})
+
+	logger.Debug("fetched metas", "count", len(results), "duration_ms", time.Since(start).Milliseconds())
+	return results, nil
+}
+
+// FetchBlocks resolves each ref to a locally-available block, downloading
+// and caching it from object storage on a cache miss. On a read-only
+// BloomStore a downloaded block is only read and validated: it is neither
+// extracted to the working directory nor cached, and its BlockDirectory has
+// an empty Path.
+func (b *BloomStore) FetchBlocks(ctx context.Context, refs []BlockRef) ([]*BlockDirectory, error) {
+	logger := b.logger.WithContext(ctx)
+
+	results := make([]*BlockDirectory, 0, len(refs))
+	for _, ref := range refs {
+		dir, err := b.fetchBlock(ctx, logger, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch block %s: %w", ref.Addr(), err)
+		}
+		results = append(results, dir)
+	}
+	return results, nil
+}
+
+func (b *BloomStore) fetchBlock(ctx context.Context, logger *Logger, ref BlockRef) (*BlockDirectory, error) {
+	start := time.Now()
+	key := ref.Addr()
+	logger = logger.With("tenant", ref.TenantID, "table", ref.TableName, "block_ref", key)
+
+	if found, dirs, _, err := b.blocksCache.Fetch(ctx, []string{key}); err == nil && len(found) > 0 {
+		logger.Debug("fetched block", "cache_hit", true, "duration_ms", time.Since(start).Milliseconds())
+		return &dirs[0], nil
+	}
+
+	dir := filepath.Join(b.workingDir, filepath.FromSlash(key))
+	var bytesRead int64
+	var blockDir BlockDirectory
+	if err := b.storeDo(ref.StartTimestamp, func(s *bloomStoreEntry) error {
+		rc, n, err := s.readClient().GetObject(ctx, key)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		bytesRead = n
+
+		if b.readOnly {
+			// Never extract to the shipper's managed working directory in
+			// read-only mode: that directory is only bounded by the
+			// write-through cache below, which is also skipped here, so
+			// writing to it would grow it unboundedly for a mirror-backed
+			// querier. Still fully read and validate the stream so a
+			// corrupted/truncated block surfaces as an error like normal.
+			blockDir = BlockDirectory{BlockRef: ref}
+			return readBlock(rc)
+		}
+
+		blockDir = BlockDirectory{BlockRef: ref, Path: dir}
+		return downloadBlock(b.fs, dir, rc)
+	}); err != nil {
+		logger.Error("failed to download block", "cache_hit", false, "err", err, "duration_ms", time.Since(start).Milliseconds())
+		return nil, err
+	}
+
+	if !b.readOnly {
+		// write-through: keep the block around locally so the next fetch
+		// is a cache hit. Disabled in read-only mode so a querier pointed
+		// at a mirror bucket never persists local state either.
+		if err := b.blocksCache.Store(ctx, []string{key}, []BlockDirectory{blockDir}); err != nil {
+			logger.Warn("failed to cache downloaded block", "err", err)
+		}
+	}
+
+	logger.Debug("fetched block", "cache_hit", false, "bytes", bytesRead, "duration_ms", time.Since(start).Milliseconds())
+	return &blockDir, nil
+}
+
+// PutMeta uploads meta to object storage. It fails immediately on a
+// read-only BloomStore.
+func (b *BloomStore) PutMeta(ctx context.Context, meta Meta) error {
+	if b.readOnly {
+		return errReadOnly
+	}
+
+	start := time.Now()
+	logger := b.logger.WithContext(ctx).With("tenant", meta.TenantID, "table", meta.TableName, "meta_ref", meta.MetaRef.Addr())
+
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshal meta: %w", err)
+	}
+
+	err = b.storeDo(meta.Ref.StartTimestamp, func(s *bloomStoreEntry) error {
+		return s.objectClient.PutObject(ctx, s.Meta(meta.MetaRef).Addr(), bytes.NewReader(raw))
+	})
+	if err != nil {
+		logger.Error("failed to put meta", "err", err, "duration_ms", time.Since(start).Milliseconds())
+		return err
+	}
+
+	logger.Debug("put meta", "bytes", len(raw), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// PutBlock uploads block's data to object storage. It fails immediately on
+// a read-only BloomStore.
+func (b *BloomStore) PutBlock(ctx context.Context, block Block) error {
+	if b.readOnly {
+		return errReadOnly
+	}
+
+	start := time.Now()
+	logger := b.logger.WithContext(ctx).With("tenant", block.TenantID, "table", block.TableName, "block_ref", block.BlockRef.Addr())
+
+	err := b.storeDo(block.Ref.StartTimestamp, func(s *bloomStoreEntry) error {
+		return s.objectClient.PutObject(ctx, s.Block(block.BlockRef).Addr(), block.Data)
+	})
+	if err != nil {
+		logger.Error("failed to put block", "err", err, "duration_ms", time.Since(start).Milliseconds())
+		return err
+	}
+
+	logger.Debug("put block", "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+func ensureWorkingDirectory(fs FS, wd string) error {
+	if err := fs.MkdirAll(wd, 0750); err != nil {
+		return fmt.Errorf("failed to create working directory %s: %w", wd, err)
+	}
+
+	probe := filepath.Join(wd, ".bloomshipper-write-probe")
+	f, err := fs.OpenFile(probe, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("insufficient permissions on working directory %s: %w", wd, err)
+	}
+	f.Close()
+	return fs.Remove(probe)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
@@ -23,10 +24,10 @@ import (
 
 func newMockBloomStore(t *testing.T) (*BloomStore, string, error) {
 	workDir := t.TempDir()
-	return newMockBloomStoreWithWorkDir(t, workDir)
+	return newMockBloomStoreWithWorkDir(t, workDir, defaultFS)
 }
 
-func newMockBloomStoreWithWorkDir(t *testing.T, workDir string) (*BloomStore, string, error) {
+func newMockBloomStoreWithWorkDir(t *testing.T, workDir string, fs FS) (*BloomStore, string, error) {
 	periodicConfigs := []storageconfig.PeriodConfig{
 		{
 			ObjectType: storageconfig.StorageTypeInMemory,
@@ -63,12 +64,12 @@ func newMockBloomStoreWithWorkDir(t *testing.T, workDir string) (*BloomStore, st
 
 	metrics := storage.NewClientMetrics()
 	t.Cleanup(metrics.Unregister)
-	logger := log.NewLogfmtLogger(os.Stderr)
+	logger := NewLogger(log.NewLogfmtLogger(os.Stderr))
 
 	metasCache := cache.NewMockCache()
 	blocksCache := NewBlocksCache(storageConfig.BloomShipperConfig.BlocksCache, prometheus.NewPedanticRegistry(), logger)
 
-	store, err := NewBloomStore(periodicConfigs, storageConfig, metrics, metasCache, blocksCache, logger)
+	store, err := NewBloomStoreWithFS(periodicConfigs, storageConfig, metrics, metasCache, blocksCache, fs, prometheus.NewPedanticRegistry(), logger)
 	if err == nil {
 		t.Cleanup(store.Stop)
 	}
@@ -97,17 +98,27 @@ func createMetaInStorage(store *BloomStore, tenant string, start model.Time, min
 	return meta, err
 }
 
-func createBlockInStorage(t *testing.T, store *BloomStore, tenant string, start model.Time, minFp, maxFp model.Fingerprint) (Block, error) {
+// buildTestBlockTarGz returns the bytes of a minimal, valid block tar.gz
+// archive, suitable for seeding a fake object client directly.
+func buildTestBlockTarGz(t *testing.T) []byte {
+	t.Helper()
 	tmpDir := t.TempDir()
-	fp, _ := os.CreateTemp(t.TempDir(), "*.tar.gz")
 
 	blockWriter := v1.NewDirectoryBlockWriter(tmpDir)
 	err := blockWriter.Init()
 	require.NoError(t, err)
 
-	err = v1.TarGz(fp, v1.NewDirectoryBlockReader(tmpDir))
+	var buf bytes.Buffer
+	err = v1.TarGz(&buf, v1.NewDirectoryBlockReader(tmpDir))
 	require.NoError(t, err)
 
+	return buf.Bytes()
+}
+
+func createBlockInStorage(t *testing.T, store *BloomStore, tenant string, start model.Time, minFp, maxFp model.Fingerprint) (Block, error) {
+	fp, _ := os.CreateTemp(t.TempDir(), "*.tar.gz")
+	_, err := fp.Write(buildTestBlockTarGz(t))
+	require.NoError(t, err)
 	_, _ = fp.Seek(0, 0)
 
 	block := Block{
@@ -269,16 +280,140 @@ func TestBloomStore_FetchBlocks(t *testing.T) {
 	)
 }
 
+func TestBloomStore_ReadOnly(t *testing.T) {
+	periodicConfigs := []storageconfig.PeriodConfig{
+		{
+			ObjectType: storageconfig.StorageTypeInMemory,
+			From:       parseDayTime("2024-01-01"),
+			IndexTables: storageconfig.IndexPeriodicTableConfig{
+				PeriodicTableConfig: storageconfig.PeriodicTableConfig{
+					Period: 24 * time.Hour,
+					Prefix: "schema_a_table_",
+				}},
+		},
+	}
+
+	storageConfig := storage.Config{
+		BloomShipperConfig: config.Config{
+			WorkingDirectory: t.TempDir(),
+			BlocksDownloadingQueue: config.DownloadingQueueConfig{
+				WorkersCount: 1,
+			},
+			BlocksCache: cache.EmbeddedCacheConfig{
+				MaxSizeItems: 1000,
+				TTL:          1 * time.Hour,
+			},
+		},
+	}
+
+	metrics := storage.NewClientMetrics()
+	t.Cleanup(metrics.Unregister)
+	logger := NewLogger(log.NewLogfmtLogger(os.Stderr))
+	metasCache := cache.NewMockCache()
+	blocksCache := NewBlocksCache(storageConfig.BloomShipperConfig.BlocksCache, prometheus.NewPedanticRegistry(), logger)
+
+	roStore, err := NewReadOnlyBloomStore(periodicConfigs, nil, storageConfig, metrics, metasCache, blocksCache, prometheus.NewPedanticRegistry(), logger)
+	require.NoError(t, err)
+	t.Cleanup(roStore.Stop)
+
+	ctx := context.Background()
+
+	err = roStore.PutMeta(ctx, Meta{})
+	require.ErrorIs(t, err, errReadOnly)
+
+	err = roStore.PutBlock(ctx, Block{})
+	require.ErrorIs(t, err, errReadOnly)
+}
+
+// TestBloomStore_MirrorRouting builds a bloomStoreEntry with distinct
+// primary and mirror clients directly (bypassing NewReadOnlyBloomStore's
+// object client construction, which this tree can't exercise end-to-end)
+// and asserts that ResolveMetas and FetchBlocks only ever read from the
+// mirror.
+func TestBloomStore_MirrorRouting(t *testing.T) {
+	cfg := storageconfig.PeriodConfig{
+		ObjectType: storageconfig.StorageTypeInMemory,
+		From:       parseDayTime("2024-01-01"),
+		IndexTables: storageconfig.IndexPeriodicTableConfig{
+			PeriodicTableConfig: storageconfig.PeriodicTableConfig{
+				Period: 24 * time.Hour,
+				Prefix: "schema_a_table_",
+			}},
+	}
+
+	logger := NewLogger(log.NewLogfmtLogger(os.Stderr))
+	metasCache := cache.NewMockCache()
+	blocksCache := NewBlocksCache(cache.EmbeddedCacheConfig{MaxSizeItems: 1000, TTL: time.Hour}, prometheus.NewPedanticRegistry(), logger)
+
+	primary := newFakeMetaClient()
+	mirror := newFakeMetaClient()
+
+	start := parseTime("2024-01-20 00:00")
+	table := tablesForRange(cfg, NewInterval(start, start.Add(12*time.Hour)))[0]
+
+	// The meta only the mirror has: this is what reads must return.
+	mirrorRef := MetaRef{Ref: Ref{TenantID: "tenant", TableName: table, Bounds: v1.NewBounds(0x00000000, 0x0000ffff), StartTimestamp: start}}
+	mirror.put(t, mirrorRef, Meta{MetaRef: mirrorRef})
+
+	// The meta only the primary has: if reads ever hit the primary, this
+	// would show up in ResolveMetas instead of/alongside mirrorRef.
+	primaryRef := MetaRef{Ref: Ref{TenantID: "tenant", TableName: table, Bounds: v1.NewBounds(0x00010000, 0x0001ffff), StartTimestamp: start}}
+	primary.put(t, primaryRef, Meta{MetaRef: primaryRef})
+
+	entry := &bloomStoreEntry{
+		cfg:          cfg,
+		objectClient: primary,
+		mirrorClient: mirror,
+	}
+	entry.fetcher = NewMetaFetcher(logger, entry.readClient(), metasCache, newFetcherMetrics(prometheus.NewPedanticRegistry()))
+
+	store := &BloomStore{
+		stores:     []*bloomStoreEntry{entry},
+		workingDir: t.TempDir(),
+		fs:         defaultFS,
+		logger:     logger,
+	}
+
+	ctx := context.Background()
+	params := MetaSearchParams{
+		TenantID: "tenant",
+		Interval: NewInterval(start, start.Add(24*time.Hour)),
+		Keyspace: v1.NewBounds(0x00000000, 0x0000ffff),
+	}
+
+	refs, fetchers, err := store.ResolveMetas(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, [][]MetaRef{{mirrorRef}}, refs)
+	require.Len(t, fetchers, 1)
+
+	// Block reads must also hit the mirror, not the primary.
+	blockRef := BlockRef{Ref: Ref{TenantID: "tenant", TableName: table, Bounds: v1.NewBounds(0x00000000, 0x0000ffff), StartTimestamp: start, EndTimestamp: start.Add(12 * time.Hour)}}
+	mirror.putRaw(blockRef.Addr(), buildTestBlockTarGz(t))
+
+	store.blocksCache = blocksCache
+	dirs, err := store.FetchBlocks(ctx, []BlockRef{blockRef})
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+
+	_, _, err = primary.GetObject(ctx, blockRef.Addr())
+	require.True(t, primary.IsObjectNotFoundErr(err), "block must not have been written to or read from the primary")
+
+	// Writes, by contrast, must always go to the primary.
+	err = store.PutMeta(ctx, Meta{MetaRef: mirrorRef})
+	require.NoError(t, err)
+	_, _, err = primary.GetObject(ctx, mirrorRef.Addr())
+	require.NoError(t, err, "meta writes must land on the primary client")
+}
+
 func TestBloomShipper_WorkingDir(t *testing.T) {
 	t.Run("insufficient permissions on directory yields error", func(t *testing.T) {
 		base := t.TempDir()
 		wd := filepath.Join(base, "notpermitted")
-		err := os.MkdirAll(wd, 0500)
-		require.NoError(t, err)
-		fi, _ := os.Stat(wd)
-		t.Log("working directory", wd, fi.Mode())
 
-		_, _, err = newMockBloomStoreWithWorkDir(t, wd)
+		fs := newFakeFS()
+		fs.failOpenFile(wd, syscall.EACCES)
+
+		_, _, err := newMockBloomStoreWithWorkDir(t, wd, fs)
 		require.ErrorContains(t, err, "insufficient permissions")
 	})
 
@@ -288,7 +423,7 @@ func TestBloomShipper_WorkingDir(t *testing.T) {
 		wd := filepath.Join(base, "doesnotexist")
 		t.Log("working directory", wd)
 
-		store, _, err := newMockBloomStoreWithWorkDir(t, wd)
+		store, _, err := newMockBloomStoreWithWorkDir(t, wd, defaultFS)
 		require.NoError(t, err)
 		b, err := createBlockInStorage(t, store, "tenant", parseTime("2024-01-20 00:00"), 0x00000000, 0x0000ffff)
 		require.NoError(t, err)
@@ -297,4 +432,34 @@ func TestBloomShipper_WorkingDir(t *testing.T) {
 		_, err = store.FetchBlocks(ctx, []BlockRef{b.BlockRef})
 		require.NoError(t, err)
 	})
+
+	t.Run("disk full while downloading a block yields error", func(t *testing.T) {
+		workDir := t.TempDir()
+		fs := newFakeFS()
+
+		store, _, err := newMockBloomStoreWithWorkDir(t, workDir, fs)
+		require.NoError(t, err)
+		b, err := createBlockInStorage(t, store, "tenant", parseTime("2024-01-20 00:00"), 0x00000000, 0x0000ffff)
+		require.NoError(t, err)
+
+		fs.failMkdirAll(filepath.Join(workDir, b.BlockRef.Addr()), syscall.ENOSPC)
+
+		_, err = store.FetchBlocks(context.Background(), []BlockRef{b.BlockRef})
+		require.ErrorIs(t, err, syscall.ENOSPC)
+	})
+
+	t.Run("transient I/O error while downloading a block yields error", func(t *testing.T) {
+		workDir := t.TempDir()
+		fs := newFakeFS()
+
+		store, _, err := newMockBloomStoreWithWorkDir(t, workDir, fs)
+		require.NoError(t, err)
+		b, err := createBlockInStorage(t, store, "tenant", parseTime("2024-01-20 00:00"), 0x00000000, 0x0000ffff)
+		require.NoError(t, err)
+
+		fs.failOpenFile(filepath.Join(workDir, b.BlockRef.Addr()), os.ErrDeadlineExceeded)
+
+		_, err = store.FetchBlocks(context.Background(), []BlockRef{b.BlockRef})
+		require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	})
 }
@@ -0,0 +1,124 @@
+package bloomshipper
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/common/model"
+
+	v1 "github.com/grafana/loki/pkg/storage/bloom/v1"
+	storageconfig "github.com/grafana/loki/pkg/storage/config"
+)
+
+// tablesForRange returns the names of every periodic table under cfg's
+// period/prefix from the one containing interval.Start up to and including
+// the one containing interval.End.
+func tablesForRange(cfg storageconfig.PeriodConfig, interval Interval) []string {
+	period := cfg.IndexTables.Period
+	if period <= 0 {
+		return nil
+	}
+
+	start := interval.Start.Time().UnixNano() / int64(period)
+	end := interval.End.Time().UnixNano() / int64(period)
+
+	var tables []string
+	for i := start; i <= end; i++ {
+		tables = append(tables, fmt.Sprintf("%s%d", cfg.IndexTables.Prefix, i))
+	}
+	return tables
+}
+
+// parseMetaRef recovers a MetaRef from the object key produced by
+// metaPath. It is the inverse used when listing metas from storage.
+func parseMetaRef(key string) (MetaRef, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) < 5 {
+		return MetaRef{}, fmt.Errorf("malformed meta key %q", key)
+	}
+
+	table, tenant := parts[1], parts[2]
+	bounds, err := v1.ParseBoundsFromAddr(parts[4])
+	if err != nil {
+		return MetaRef{}, fmt.Errorf("parse bounds from meta key %q: %w", key, err)
+	}
+
+	// metaPath appends the start timestamp after the last "-".
+	var start model.Time
+	if err := start.UnmarshalJSON([]byte(parts[4][strings.LastIndex(parts[4], "-")+1:])); err != nil {
+		return MetaRef{}, fmt.Errorf("parse start timestamp from meta key %q: %w", key, err)
+	}
+
+	return MetaRef{Ref: Ref{TenantID: tenant, TableName: table, Bounds: bounds, StartTimestamp: start}}, nil
+}
+
+// readBlock validates that r is a well-formed tar.gz block stream without
+// writing anything to disk, for callers (read-only stores) that must not
+// extract downloaded blocks to local storage.
+func readBlock(r io.Reader) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("open gzip stream: %w", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		if _, err := tr.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read tar entry: %w", err)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return fmt.Errorf("read tar entry: %w", err)
+		}
+	}
+}
+
+// downloadBlock unpacks the tar.gz stream r into dir using fs, creating the
+// directory tree if necessary.
+func downloadBlock(fs FS, dir string, r io.Reader) error {
+	if err := fs.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("create block directory %s: %w", dir, err)
+	}
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("open gzip stream: %w", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar entry: %w", err)
+		}
+
+		target := filepath.Join(dir, filepath.Clean(hdr.Name))
+		if hdr.Typeflag == tar.TypeDir {
+			if err := fs.MkdirAll(target, 0750); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, err := fs.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+		if err != nil {
+			return fmt.Errorf("write block file %s: %w", target, err)
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return fmt.Errorf("write block file %s: %w", target, err)
+		}
+		f.Close()
+	}
+}